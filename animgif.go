@@ -0,0 +1,231 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// isGIFPath reports whether path looks like a GIF file based on its
+// extension, used to route -i1/-i2 into the animated XOR subsystem.
+func isGIFPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".gif")
+}
+
+func decodeGIFFile(path string) *gif.GIF {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Error opening GIF %s: %v", path, err)
+	}
+	defer f.Close()
+
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		log.Fatalf("Error decoding GIF %s: %v", path, err)
+	}
+	return g
+}
+
+// compositeGIFFrames decodes each frame of g onto a full-canvas buffer
+// sized to g's logical screen, honoring each frame's Disposal method, and
+// returns one full-size RGBA image per frame. GIF encoders routinely emit
+// frames as small sub-rectangles covering only the changed region, so
+// frame bounds can't be compared or XORed directly without first
+// reconstituting the actual visible canvas this way.
+func compositeGIFFrames(g *gif.GIF) []*image.RGBA {
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	var saved *image.RGBA
+
+	frames := make([]*image.RGBA, len(g.Image))
+	for i, frame := range g.Image {
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		if disposal == gif.DisposalPrevious {
+			saved = image.NewRGBA(canvas.Bounds())
+			copy(saved.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		out := image.NewRGBA(canvas.Bounds())
+		copy(out.Pix, canvas.Pix)
+		frames[i] = out
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			if saved != nil {
+				copy(canvas.Pix, saved.Pix)
+			}
+		}
+	}
+	return frames
+}
+
+// xorAnimatedGIFs composites two animated GIFs frame by frame using mode
+// and offset (the same compositing modes -mode/-offset apply to still
+// images), preserving the delay, disposal, and loop count of the first
+// input, and writes the result to stdout as a new animated GIF.
+func xorAnimatedGIFs(path1, path2, fit, paletteMode, mode string, offX, offY int) {
+	g1 := decodeGIFFile(path1)
+	g2 := decodeGIFFile(path2)
+
+	if len(g1.Image) != len(g2.Image) {
+		log.Fatalf("Error: GIFs have different frame counts: %d vs %d", len(g1.Image), len(g2.Image))
+	}
+
+	canvases1 := compositeGIFFrames(g1)
+	canvases2 := compositeGIFFrames(g2)
+
+	frames := make([]*image.RGBA, len(canvases1))
+	for i := range canvases1 {
+		f1, f2 := canvases1[i], canvases2[i]
+
+		if mode == "xor" && offX == 0 && offY == 0 {
+			b1, b2 := f1.Bounds(), f2.Bounds()
+			if b1.Dx() != b2.Dx() || b1.Dy() != b2.Dy() {
+				if fit == "none" {
+					log.Fatalf("Error: GIF canvases have different dimensions\nImage1: %dx%d\nImage2: %dx%d",
+						b1.Dx(), b1.Dy(), b2.Dx(), b2.Dy())
+				}
+				f1, f2 = fitImages(f1, f2, fit)
+			}
+		}
+
+		frames[i] = compositeImages(f1, f2, mode, offX, offY)
+	}
+
+	out := &gif.GIF{LoopCount: g1.LoopCount}
+
+	var globalPalette color.Palette
+	if paletteMode == "global" {
+		globalPalette = medianCutPalette(frames, 256)
+	}
+
+	for i, frame := range frames {
+		pal := globalPalette
+		if paletteMode != "global" {
+			pal = medianCutPalette([]*image.RGBA{frame}, 256)
+		}
+		out.Image = append(out.Image, quantizeFrame(frame, pal))
+		out.Delay = append(out.Delay, g1.Delay[i])
+		out.Disposal = append(out.Disposal, g1.Disposal[i])
+	}
+
+	if err := gif.EncodeAll(os.Stdout, out); err != nil {
+		log.Fatalf("Error encoding animated GIF: %v", err)
+	}
+}
+
+// quantizeFrame maps frame onto pal, taking the nearest palette entry for
+// each pixel via the standard library's paletted drawing.
+func quantizeFrame(frame *image.RGBA, pal color.Palette) *image.Paletted {
+	dst := image.NewPaletted(frame.Bounds(), pal)
+	draw.Draw(dst, frame.Bounds(), frame, frame.Bounds().Min, draw.Src)
+	return dst
+}
+
+// colorBox is a bucket of pixels used by the median-cut quantizer.
+type colorBox struct {
+	pixels []color.RGBA
+}
+
+// medianCutPalette builds a palette of up to maxColors entries from the
+// given frames using median-cut: the box with the widest channel range is
+// repeatedly split at its median until no more splits are useful.
+func medianCutPalette(frames []*image.RGBA, maxColors int) color.Palette {
+	var pixels []color.RGBA
+	for _, f := range frames {
+		for i := 0; i < len(f.Pix); i += 4 {
+			pixels = append(pixels, color.RGBA{f.Pix[i], f.Pix[i+1], f.Pix[i+2], f.Pix[i+3]})
+		}
+	}
+	if len(pixels) == 0 {
+		return color.Palette{color.RGBA{0, 0, 0, 255}}
+	}
+
+	boxes := []colorBox{{pixels: pixels}}
+	for len(boxes) < maxColors {
+		splitIdx, bestRange := -1, -1
+		for i, b := range boxes {
+			if len(b.pixels) < 2 {
+				continue
+			}
+			if _, r := widestChannel(b.pixels); r > bestRange {
+				bestRange, splitIdx = r, i
+			}
+		}
+		if splitIdx == -1 {
+			break
+		}
+
+		b := boxes[splitIdx]
+		ch, _ := widestChannel(b.pixels)
+		sort.Slice(b.pixels, func(i, j int) bool {
+			return channelValue(b.pixels[i], ch) < channelValue(b.pixels[j], ch)
+		})
+		mid := len(b.pixels) / 2
+		boxes[splitIdx] = colorBox{pixels: b.pixels[:mid]}
+		boxes = append(boxes, colorBox{pixels: b.pixels[mid:]})
+	}
+
+	pal := make(color.Palette, 0, len(boxes))
+	for _, b := range boxes {
+		pal = append(pal, averageColor(b.pixels))
+	}
+	return pal
+}
+
+// widestChannel returns which of R(0)/G(1)/B(2) has the largest range in
+// pixels, along with that range.
+func widestChannel(pixels []color.RGBA) (channel, rng int) {
+	minR, maxR := 255, 0
+	minG, maxG := 255, 0
+	minB, maxB := 255, 0
+	for _, p := range pixels {
+		minR, maxR = minInt(minR, int(p.R)), maxInt(maxR, int(p.R))
+		minG, maxG = minInt(minG, int(p.G)), maxInt(maxG, int(p.G))
+		minB, maxB = minInt(minB, int(p.B)), maxInt(maxB, int(p.B))
+	}
+	rR, rG, rB := maxR-minR, maxG-minG, maxB-minB
+	if rR >= rG && rR >= rB {
+		return 0, rR
+	}
+	if rG >= rB {
+		return 1, rG
+	}
+	return 2, rB
+}
+
+func channelValue(p color.RGBA, channel int) uint8 {
+	switch channel {
+	case 0:
+		return p.R
+	case 1:
+		return p.G
+	default:
+		return p.B
+	}
+}
+
+func averageColor(pixels []color.RGBA) color.RGBA {
+	var sr, sg, sb, sa int
+	for _, p := range pixels {
+		sr += int(p.R)
+		sg += int(p.G)
+		sb += int(p.B)
+		sa += int(p.A)
+	}
+	n := len(pixels)
+	return color.RGBA{uint8(sr / n), uint8(sg / n), uint8(sb / n), uint8(sa / n)}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func palettedFrame(rect image.Rectangle, c color.RGBA) *image.Paletted {
+	img := image.NewPaletted(rect, color.Palette{c})
+	for i := range img.Pix {
+		img.Pix[i] = 0
+	}
+	return img
+}
+
+func TestCompositeGIFFramesHandlesSubRectangles(t *testing.T) {
+	full := color.RGBA{50, 50, 50, 255}
+	sub := color.RGBA{100, 100, 100, 255}
+
+	g := &gif.GIF{
+		Config: image.Config{Width: 8, Height: 8},
+		Image: []*image.Paletted{
+			palettedFrame(image.Rect(0, 0, 8, 8), full),
+			palettedFrame(image.Rect(2, 2, 6, 6), sub),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Delay:    []int{0, 0},
+	}
+
+	frames := compositeGIFFrames(g)
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	for _, f := range frames {
+		if f.Bounds().Dx() != 8 || f.Bounds().Dy() != 8 {
+			t.Fatalf("frame bounds = %v, want 8x8 (full canvas)", f.Bounds())
+		}
+	}
+
+	// The second frame's sub-rectangle overwrites (2,2)-(6,6)...
+	if got := frames[1].RGBAAt(3, 3); got != sub {
+		t.Fatalf("inside sub-rect = %v, want %v", got, sub)
+	}
+	// ...but everything outside it still shows the first frame underneath.
+	if got := frames[1].RGBAAt(0, 0); got != full {
+		t.Fatalf("outside sub-rect = %v, want %v (carried over from frame 0)", got, full)
+	}
+}
+
+func TestCompositeGIFFramesDisposalBackgroundClearsFrame(t *testing.T) {
+	c := color.RGBA{200, 0, 0, 255}
+
+	g := &gif.GIF{
+		Config: image.Config{Width: 4, Height: 4},
+		Image: []*image.Paletted{
+			palettedFrame(image.Rect(0, 0, 4, 4), c),
+			palettedFrame(image.Rect(0, 0, 4, 4), color.RGBA{}),
+		},
+		Disposal: []byte{gif.DisposalBackground, gif.DisposalNone},
+		Delay:    []int{0, 0},
+	}
+
+	frames := compositeGIFFrames(g)
+	// Frame 0 itself should still show its own color...
+	if got := frames[0].RGBAAt(0, 0); got != c {
+		t.Fatalf("frame 0 = %v, want %v", got, c)
+	}
+	// ...but DisposalBackground clears the canvas before frame 1 draws, so
+	// frame 1's transparent pixels should read back as transparent, not a
+	// ghost of frame 0's color.
+	if got := frames[1].RGBAAt(0, 0); got.A != 0 {
+		t.Fatalf("frame 1 after DisposalBackground = %v, want fully transparent", got)
+	}
+}
+
+func TestCompositeGIFFramesDisposalPreviousRestoresCanvas(t *testing.T) {
+	base := color.RGBA{10, 20, 30, 255}
+	overlay := color.RGBA{200, 200, 200, 255}
+
+	g := &gif.GIF{
+		Config: image.Config{Width: 4, Height: 4},
+		Image: []*image.Paletted{
+			palettedFrame(image.Rect(0, 0, 4, 4), base),
+			palettedFrame(image.Rect(1, 1, 3, 3), overlay),
+			palettedFrame(image.Rect(0, 0, 1, 1), overlay),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+		Delay:    []int{0, 0, 0},
+	}
+
+	frames := compositeGIFFrames(g)
+	// Frame 2 should see the canvas restored to how it looked before frame
+	// 1's overlay was drawn (DisposalPrevious), i.e. the plain base color
+	// outside frame 2's own 1x1 patch.
+	if got := frames[2].RGBAAt(3, 3); got != base {
+		t.Fatalf("frame 2 after DisposalPrevious = %v, want restored base %v", got, base)
+	}
+}
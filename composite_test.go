@@ -0,0 +1,59 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestBlendPixelXOR(t *testing.T) {
+	p1 := color.RGBA{100, 200, 50, 255}
+	p2 := color.RGBA{50, 150, 200, 255}
+	got := blendPixel("xor", p1, p2)
+	want := color.RGBA{100 ^ 50, 200 ^ 150, 50 ^ 200, 255}
+	if got != want {
+		t.Fatalf("xor blend = %v, want %v", got, want)
+	}
+}
+
+func TestBlendPixelAddSaturates(t *testing.T) {
+	got := blendPixel("add", color.RGBA{200, 0, 0, 255}, color.RGBA{100, 0, 0, 255})
+	if got.R != 255 {
+		t.Fatalf("add blend R = %d, want saturated 255", got.R)
+	}
+}
+
+func TestBlendPixelSubClampsAtZero(t *testing.T) {
+	got := blendPixel("sub", color.RGBA{50, 0, 0, 255}, color.RGBA{100, 0, 0, 255})
+	if got.R != 0 {
+		t.Fatalf("sub blend R = %d, want clamped 0", got.R)
+	}
+}
+
+func TestPorterDuffOverOpaqueSrcWins(t *testing.T) {
+	dst := color.RGBA{0, 0, 255, 255} // opaque blue
+	src := color.RGBA{255, 0, 0, 255} // opaque red
+	got := porterDuffOver(dst, src)
+	want := color.RGBA{255, 0, 0, 255}
+	if got != want {
+		t.Fatalf("over with opaque src = %v, want %v (src replaces dst)", got, want)
+	}
+}
+
+func TestPorterDuffOverHalfAlphaBlend(t *testing.T) {
+	dst := color.RGBA{0, 0, 255, 255} // opaque blue
+	src := color.RGBA{128, 0, 0, 128} // ~50% alpha red, premultiplied
+	got := porterDuffOver(dst, src)
+	want := color.RGBA{128, 0, 127, 255}
+	if got != want {
+		t.Fatalf("over with 50%% alpha src = %v, want %v", got, want)
+	}
+}
+
+func TestPorterDuffOverTransparentSrcIsNoOp(t *testing.T) {
+	dst := color.RGBA{10, 20, 30, 255}
+	src := color.RGBA{0, 0, 0, 0}
+	got := porterDuffOver(dst, src)
+	if got != dst {
+		t.Fatalf("over with fully transparent src = %v, want dst unchanged %v", got, dst)
+	}
+}
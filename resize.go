@@ -0,0 +1,205 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+)
+
+// lanczosA is the Lanczos kernel's window radius (a=3 gives a 6-tap filter).
+const lanczosA = 3.0
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	px := math.Pi * x
+	return math.Sin(px) / px
+}
+
+// lanczosWeight evaluates the Lanczos-3 kernel at x.
+func lanczosWeight(x float64) float64 {
+	if x <= -lanczosA || x >= lanczosA {
+		return 0
+	}
+	return sinc(x) * sinc(x/lanczosA)
+}
+
+// lanczosResize resamples src to the given dimensions using a separable
+// Lanczos-3 filter, with the support widened when downsampling to avoid
+// aliasing.
+func lanczosResize(src *image.RGBA, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == width && srcH == height {
+		return src
+	}
+
+	horizontal := resampleAxis(src, width, srcH, true)
+	return resampleAxis(horizontal, width, height, false)
+}
+
+// resampleAxis resamples src along one axis (x when horizontal is true,
+// otherwise y), producing an image of outW x outH.
+func resampleAxis(src *image.RGBA, outW, outH int, horizontal bool) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, outW, outH))
+
+	var dstLen int
+	var scale float64
+	if horizontal {
+		dstLen = outW
+		scale = float64(srcW) / float64(outW)
+	} else {
+		dstLen = outH
+		scale = float64(srcH) / float64(outH)
+	}
+
+	support := lanczosA
+	filterScale := scale
+	if filterScale < 1 {
+		filterScale = 1 // don't widen the support when upsampling
+	}
+	radius := int(math.Ceil(support * filterScale))
+
+	for out := 0; out < dstLen; out++ {
+		center := (float64(out)+0.5)*scale - 0.5
+		lo := int(math.Floor(center)) - radius
+		hi := int(math.Floor(center)) + radius + 1
+
+		weights := make([]float64, hi-lo)
+		var sum float64
+		for i := lo; i < hi; i++ {
+			w := lanczosWeight((float64(i) - center) / filterScale)
+			weights[i-lo] = w
+			sum += w
+		}
+		if sum == 0 {
+			sum = 1
+		}
+
+		if horizontal {
+			for y := 0; y < srcH; y++ {
+				var r, g, b, a float64
+				for i := lo; i < hi; i++ {
+					sx := clampInt(i, 0, srcW-1)
+					w := weights[i-lo] / sum
+					px := src.RGBAAt(bounds.Min.X+sx, bounds.Min.Y+y)
+					r += float64(px.R) * w
+					g += float64(px.G) * w
+					b += float64(px.B) * w
+					a += float64(px.A) * w
+				}
+				dst.SetRGBA(out, y, clampPixel(r, g, b, a))
+			}
+		} else {
+			for x := 0; x < srcW; x++ {
+				var r, g, b, a float64
+				for i := lo; i < hi; i++ {
+					sy := clampInt(i, 0, srcH-1)
+					w := weights[i-lo] / sum
+					px := src.RGBAAt(bounds.Min.X+x, bounds.Min.Y+sy)
+					r += float64(px.R) * w
+					g += float64(px.G) * w
+					b += float64(px.B) * w
+					a += float64(px.A) * w
+				}
+				dst.SetRGBA(x, out, clampPixel(r, g, b, a))
+			}
+		}
+	}
+	return dst
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func clampByte(v float64) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+func clampPixel(r, g, b, a float64) color.RGBA {
+	return color.RGBA{clampByte(r), clampByte(g), clampByte(b), clampByte(a)}
+}
+
+// centerCrop crops img to a w x h region centered within its bounds.
+func centerCrop(img *image.RGBA, w, h int) *image.RGBA {
+	bounds := img.Bounds()
+	offX := (bounds.Dx() - w) / 2
+	offY := (bounds.Dy() - h) / 2
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.SetRGBA(x, y, img.RGBAAt(bounds.Min.X+offX+x, bounds.Min.Y+offY+y))
+		}
+	}
+	return dst
+}
+
+// padImage letterboxes img within a w x h canvas, centering it and
+// leaving the border fully zeroed (which XORs to identity).
+func padImage(img *image.RGBA, w, h int) *image.RGBA {
+	bounds := img.Bounds()
+	offX := (w - bounds.Dx()) / 2
+	offY := (h - bounds.Dy()) / 2
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			dst.SetRGBA(offX+x, offY+y, img.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// fitImages reconciles mismatched bounds between img1 and img2 according
+// to mode ("crop", "pad", or "resize"), returning adjusted copies with
+// identical bounds. mode "none" is rejected by the caller before this is
+// reached.
+func fitImages(img1, img2 *image.RGBA, mode string) (*image.RGBA, *image.RGBA) {
+	b1, b2 := img1.Bounds(), img2.Bounds()
+
+	switch mode {
+	case "crop":
+		w, h := minInt(b1.Dx(), b2.Dx()), minInt(b1.Dy(), b2.Dy())
+		return centerCrop(img1, w, h), centerCrop(img2, w, h)
+	case "pad":
+		w, h := maxInt(b1.Dx(), b2.Dx()), maxInt(b1.Dy(), b2.Dy())
+		return padImage(img1, w, h), padImage(img2, w, h)
+	case "resize":
+		return img1, lanczosResize(img2, b1.Dx(), b1.Dy())
+	default:
+		log.Fatalf("Error: unknown -fit mode %q (want none, crop, pad, or resize)", mode)
+		return nil, nil
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
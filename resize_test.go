@@ -0,0 +1,76 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func solidImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestLanczosResizePreservesSolidFill(t *testing.T) {
+	c := color.RGBA{60, 120, 180, 255}
+	for _, dims := range [][2]int{{8, 8}, {20, 20}, {3, 3}} {
+		src := solidImage(10, 10, c)
+		out := lanczosResize(src, dims[0], dims[1])
+
+		b := out.Bounds()
+		if b.Dx() != dims[0] || b.Dy() != dims[1] {
+			t.Fatalf("resize to %v: got bounds %v", dims, b)
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				got := out.RGBAAt(x, y)
+				if got != c {
+					t.Fatalf("resize to %v: pixel (%d,%d) = %v, want %v (solid fill must resample to itself)", dims, x, y, got, c)
+				}
+			}
+		}
+	}
+}
+
+func TestLanczosResizeNoOpWhenDimensionsMatch(t *testing.T) {
+	src := solidImage(5, 5, color.RGBA{1, 2, 3, 4})
+	out := lanczosResize(src, 5, 5)
+	if out != src {
+		t.Fatal("resizing to the same dimensions should return src unchanged")
+	}
+}
+
+func TestFitImagesCropMatchesSmallerBounds(t *testing.T) {
+	img1 := solidImage(10, 10, color.RGBA{255, 0, 0, 255})
+	img2 := solidImage(6, 8, color.RGBA{0, 255, 0, 255})
+
+	out1, out2 := fitImages(img1, img2, "crop")
+	if out1.Bounds().Dx() != 6 || out1.Bounds().Dy() != 8 {
+		t.Fatalf("cropped img1 bounds = %v, want 6x8", out1.Bounds())
+	}
+	if out2.Bounds() != out1.Bounds() {
+		t.Fatalf("crop produced mismatched bounds: %v vs %v", out1.Bounds(), out2.Bounds())
+	}
+}
+
+func TestFitImagesPadMatchesLargerBounds(t *testing.T) {
+	img1 := solidImage(10, 10, color.RGBA{255, 0, 0, 255})
+	img2 := solidImage(6, 8, color.RGBA{0, 255, 0, 255})
+
+	out1, out2 := fitImages(img1, img2, "pad")
+	if out1.Bounds().Dx() != 10 || out1.Bounds().Dy() != 10 {
+		t.Fatalf("padded img1 bounds = %v, want 10x10", out1.Bounds())
+	}
+	if out2.Bounds() != out1.Bounds() {
+		t.Fatalf("pad produced mismatched bounds: %v vs %v", out1.Bounds(), out2.Bounds())
+	}
+	// The padded border is left zeroed so it XORs to identity.
+	if out2.RGBAAt(0, 0) != (color.RGBA{}) {
+		t.Fatalf("pad border pixel = %v, want zero value", out2.RGBAAt(0, 0))
+	}
+}
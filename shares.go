@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// generateRandomShareImage produces a crypto/rand noise image like
+// generateRandomImage, but also randomizes the alpha channel. A share's
+// alpha can't be left at a constant 255 the way -g's noise images are:
+// the derived final share XORs the real alpha channel against it, and a
+// constant XORs away to nothing, leaking the original alpha to anyone
+// holding just that one share.
+func generateRandomShareImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if _, err := rand.Read(img.Pix); err != nil {
+		log.Fatalf("Error generating random share data: %v", err)
+	}
+	return img
+}
+
+// genShares splits img into n share images such that XORing all of them
+// together reconstructs img. The first n-1 shares are independent
+// crypto/rand noise; the last is derived so the XOR identity holds, which
+// is what makes any proper subset indistinguishable from noise.
+func genShares(img *image.RGBA, n int) []*image.RGBA {
+	bounds := img.Bounds()
+	shares := make([]*image.RGBA, n)
+	for i := 0; i < n-1; i++ {
+		shares[i] = generateRandomShareImage(bounds.Dx(), bounds.Dy())
+	}
+
+	last := image.NewRGBA(bounds)
+	copy(last.Pix, img.Pix)
+	for i := 0; i < n-1; i++ {
+		for p := 0; p < len(last.Pix); p++ {
+			last.Pix[p] ^= shares[i].Pix[p]
+		}
+	}
+	shares[n-1] = last
+	return shares
+}
+
+// shareHash returns a short random hex identifier used to group a set of
+// shares produced from the same -shares invocation.
+func shareHash() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Error generating share id: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func saveShares(img *image.RGBA, n int) {
+	if n < 2 {
+		log.Fatalf("Error: -shares requires N >= 2")
+	}
+
+	shares := genShares(img, n)
+	hash := shareHash()
+	for i, share := range shares {
+		filename := fmt.Sprintf("k-%s-%d.png", hash, i+1)
+		f, err := os.Create(filename)
+		if err != nil {
+			log.Fatalf("Error creating file %s: %v", filename, err)
+		}
+
+		if err := png.Encode(f, shareAsNRGBA(share)); err != nil {
+			f.Close()
+			log.Fatalf("Error encoding share %s: %v", filename, err)
+		}
+
+		f.Close()
+		absPath, _ := filepath.Abs(filename)
+		fmt.Printf("Generated: %s\n", absPath)
+	}
+}
+
+// combineSharePaths resolves the -combine argument into a list of PNG
+// paths. A plain path is treated as a directory and globbed for
+// k-<hash>-*.png shares; anything containing a comma is treated as an
+// explicit comma-separated list of paths.
+func combineSharePaths(arg string) []string {
+	if strings.Contains(arg, ",") {
+		return strings.Split(arg, ",")
+	}
+
+	if info, err := os.Stat(arg); err == nil && info.IsDir() {
+		matches, err := filepath.Glob(filepath.Join(arg, "k-*-*.png"))
+		if err != nil {
+			log.Fatalf("Error globbing share directory %s: %v", arg, err)
+		}
+		return matches
+	}
+
+	return []string{arg}
+}
+
+// shareAsNRGBA reinterprets share's Pix bytes as *image.NRGBA, without
+// copying. png.Encode treats *image.RGBA as alpha-premultiplied: for any
+// pixel whose alpha isn't 0 or 255 it divides R/G/B by alpha before
+// writing, and for alpha == 0 it zeroes R/G/B outright. Share bytes are
+// crypto/rand noise with no such relationship between channels, so
+// encoding a share as *image.RGBA silently destroys data. *image.NRGBA
+// has an identical memory layout but is written out byte-for-byte.
+func shareAsNRGBA(share *image.RGBA) *image.NRGBA {
+	return &image.NRGBA{Pix: share.Pix, Stride: share.Stride, Rect: share.Rect}
+}
+
+// decodeShareImage reads a share's raw straight-alpha pixel bytes back out
+// of a decoded PNG. It deliberately avoids imageToRGBA: that helper calls
+// src.At(x,y).RGBA(), which alpha-premultiplies every non-opaque pixel.
+// Share bytes are crypto/rand noise, not real premultiplied color, so
+// premultiplying them on decode corrupts every byte whose alpha isn't 255
+// and breaks the XOR identity genShares/combineShares rely on. PNG decodes
+// a share as *image.RGBA only when every pixel happens to be fully opaque;
+// otherwise it comes back as *image.NRGBA, whose Pix is already the exact
+// straight-alpha bytes that were encoded, so both cases just need a copy.
+func decodeShareImage(src image.Image) *image.RGBA {
+	switch px := src.(type) {
+	case *image.RGBA:
+		img := image.NewRGBA(px.Bounds())
+		copy(img.Pix, px.Pix)
+		return img
+	case *image.NRGBA:
+		img := image.NewRGBA(px.Bounds())
+		copy(img.Pix, px.Pix)
+		return img
+	default:
+		log.Fatalf("Error: share image has unexpected pixel format %T", src)
+		return nil
+	}
+}
+
+// combineShares decodes every listed PNG, verifies they share identical
+// bounds, and returns their XOR.
+func combineShares(paths []string) *image.RGBA {
+	if len(paths) < 2 {
+		log.Fatalf("Error: -combine requires at least 2 shares, got %d", len(paths))
+	}
+
+	var result *image.RGBA
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Error opening share %s: %v", path, err)
+		}
+
+		decoded, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Error decoding share %s: %v", path, err)
+		}
+		share := decodeShareImage(decoded)
+
+		if result == nil {
+			result = image.NewRGBA(share.Bounds())
+			copy(result.Pix, share.Pix)
+			continue
+		}
+
+		if result.Bounds() != share.Bounds() {
+			log.Fatalf("Error: share %s has bounds %v, expected %v", path, share.Bounds(), result.Bounds())
+		}
+		for p := 0; p < len(result.Pix); p++ {
+			result.Pix[p] ^= share.Pix[p]
+		}
+	}
+	return result
+}
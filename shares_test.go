@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestGenSharesReconstructsOriginal(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			a := uint8(255)
+			if (x+y)%2 == 0 {
+				a = 0
+			}
+			img.Set(x, y, color.RGBA{100, 150, 200, a})
+		}
+	}
+
+	shares := genShares(img, 3)
+	if len(shares) != 3 {
+		t.Fatalf("got %d shares, want 3", len(shares))
+	}
+
+	got := make([]byte, len(img.Pix))
+	copy(got, shares[0].Pix)
+	for _, s := range shares[1:] {
+		for p := range got {
+			got[p] ^= s.Pix[p]
+		}
+	}
+
+	for p := range img.Pix {
+		if got[p] != img.Pix[p] {
+			t.Fatalf("byte %d: XOR of shares = %d, want %d (original)", p, got[p], img.Pix[p])
+		}
+	}
+}
+
+func TestGenSharesDoesNotLeakAlpha(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			a := uint8(255)
+			if (x+y)%2 == 0 {
+				a = 0
+			}
+			img.Set(x, y, color.RGBA{100, 150, 200, a})
+		}
+	}
+
+	shares := genShares(img, 3)
+	last := shares[len(shares)-1]
+
+	match := 0
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if last.RGBAAt(x, y).A == img.RGBAAt(x, y).A {
+				match++
+			}
+		}
+	}
+	if match == 16 {
+		t.Fatalf("last share's alpha channel is byte-identical to the original image's alpha channel; noise shares must randomize alpha too")
+	}
+}
+
+// TestCombineSharesRoundTripsThroughPNG exercises genShares/combineShares
+// the way the real CLI does: each share is PNG-encoded then decoded back
+// before being XORed. Shares have mixed alpha now that noise randomizes
+// it, so a non-opaque share decodes as *image.NRGBA; decoding it via the
+// wrong path (premultiplying alpha) corrupts the XOR identity even though
+// the in-memory-only genShares test above still passes.
+func TestCombineSharesRoundTripsThroughPNG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			a := uint8(255)
+			if (x+y)%2 == 0 {
+				a = 0
+			}
+			img.Set(x, y, color.RGBA{100, 150, 200, a})
+		}
+	}
+
+	shares := genShares(img, 3)
+
+	var decoded []image.Image
+	for _, s := range shares {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, shareAsNRGBA(s)); err != nil {
+			t.Fatalf("encoding share: %v", err)
+		}
+		d, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("decoding share: %v", err)
+		}
+		decoded = append(decoded, d)
+	}
+
+	result := decodeShareImage(decoded[0])
+	for _, d := range decoded[1:] {
+		share := decodeShareImage(d)
+		for p := range result.Pix {
+			result.Pix[p] ^= share.Pix[p]
+		}
+	}
+
+	for p := range img.Pix {
+		if result.Pix[p] != img.Pix[p] {
+			t.Fatalf("byte %d: XOR of PNG-round-tripped shares = %d, want %d (original)", p, result.Pix[p], img.Pix[p])
+		}
+	}
+
+	// The real -combine path also PNG-encodes the reconstructed result
+	// (e.g. to stdout); that encode must not corrupt it either.
+	var out bytes.Buffer
+	if err := png.Encode(&out, shareAsNRGBA(result)); err != nil {
+		t.Fatalf("encoding combined result: %v", err)
+	}
+	final, err := png.Decode(&out)
+	if err != nil {
+		t.Fatalf("decoding combined result: %v", err)
+	}
+	got := decodeShareImage(final)
+	for p := range img.Pix {
+		if got.Pix[p] != img.Pix[p] {
+			t.Fatalf("byte %d: combined result after final PNG encode = %d, want %d (original)", p, got.Pix[p], img.Pix[p])
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+)
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// idatReader concatenates the payloads of every IDAT chunk in a PNG file
+// into a single byte stream, skipping any other chunk types it encounters
+// along the way, so a zlib.Reader can be driven over it without ever
+// holding the whole compressed stream in memory at once.
+type idatReader struct {
+	r         io.Reader
+	remaining []byte
+	done      bool
+}
+
+func (ir *idatReader) Read(p []byte) (int, error) {
+	for len(ir.remaining) == 0 {
+		if ir.done {
+			return 0, io.EOF
+		}
+		typ, data, err := readChunk(ir.r)
+		if err != nil {
+			return 0, err
+		}
+		switch typ {
+		case "IEND":
+			ir.done = true
+			return 0, io.EOF
+		case "IDAT":
+			ir.remaining = data
+		}
+	}
+	n := copy(p, ir.remaining)
+	ir.remaining = ir.remaining[n:]
+	return n, nil
+}
+
+func readChunk(r io.Reader) (typ string, data []byte, err error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return "", nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	typ = string(header[4:8])
+
+	data = make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", nil, err
+	}
+	var crc [4]byte
+	if _, err := io.ReadFull(r, crc[:]); err != nil {
+		return "", nil, err
+	}
+	return typ, data, nil
+}
+
+// pngStreamDecoder reads an 8-bit truecolor (with or without alpha) PNG
+// one scanline at a time, reversing the PNG filter for each row as it is
+// pulled rather than decoding the whole image up front.
+type pngStreamDecoder struct {
+	width, height int
+	bpp           int // bytes per pixel in the source: 3 (RGB) or 4 (RGBA)
+	zr            io.ReadCloser
+	prevRow       []byte
+	y             int
+}
+
+func newPNGStreamDecoder(path string) (*pngStreamDecoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig [8]byte
+	if _, err := io.ReadFull(f, sig[:]); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !bytes.Equal(sig[:], pngSignature) {
+		f.Close()
+		return nil, errors.New("not a PNG file")
+	}
+
+	typ, data, err := readChunk(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if typ != "IHDR" || len(data) < 13 {
+		f.Close()
+		return nil, errors.New("missing IHDR chunk")
+	}
+
+	width := int(binary.BigEndian.Uint32(data[0:4]))
+	height := int(binary.BigEndian.Uint32(data[4:8]))
+	bitDepth := data[8]
+	colorType := data[9]
+	interlace := data[12]
+
+	if interlace != 0 {
+		f.Close()
+		return nil, errors.New("-stream does not support Adam7-interlaced PNGs; omit -stream for this file")
+	}
+
+	var bpp int
+	switch {
+	case bitDepth == 8 && colorType == 2:
+		bpp = 3
+	case bitDepth == 8 && colorType == 6:
+		bpp = 4
+	default:
+		f.Close()
+		return nil, errors.New("-stream only supports 8-bit RGB or RGBA PNGs; omit -stream for this file")
+	}
+
+	zr, err := zlib.NewReader(&idatReader{r: f})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &pngStreamDecoder{
+		width:  width,
+		height: height,
+		bpp:    bpp,
+		zr:     zr,
+	}, nil
+}
+
+func paethPredictor(a, b, c int) int {
+	p := a + b - c
+	pa, pb, pc := abs(p-a), abs(p-b), abs(p-c)
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// nextRow reads, unfilters, and returns the next scanline expanded to one
+// RGBA byte quadruple per pixel (alpha forced to 255 for RGB sources).
+func (d *pngStreamDecoder) nextRow() ([]byte, error) {
+	if d.y >= d.height {
+		return nil, io.EOF
+	}
+
+	rowLen := d.width * d.bpp
+	raw := make([]byte, rowLen+1)
+	if _, err := io.ReadFull(d.zr, raw); err != nil {
+		return nil, err
+	}
+	filter := raw[0]
+	cur := raw[1:]
+
+	if d.prevRow == nil {
+		d.prevRow = make([]byte, rowLen)
+	}
+
+	for i := 0; i < rowLen; i++ {
+		var a, b, c int
+		if i >= d.bpp {
+			a = int(cur[i-d.bpp])
+			c = int(d.prevRow[i-d.bpp])
+		}
+		b = int(d.prevRow[i])
+
+		switch filter {
+		case 0: // None
+		case 1: // Sub
+			cur[i] += byte(a)
+		case 2: // Up
+			cur[i] += byte(b)
+		case 3: // Average
+			cur[i] += byte((a + b) / 2)
+		case 4: // Paeth
+			cur[i] += byte(paethPredictor(a, b, c))
+		default:
+			return nil, errors.New("unsupported PNG filter type")
+		}
+	}
+
+	d.prevRow = cur
+	d.y++
+
+	if d.bpp == 4 {
+		return cur, nil
+	}
+
+	rgba := make([]byte, d.width*4)
+	for px := 0; px < d.width; px++ {
+		copy(rgba[px*4:px*4+3], cur[px*3:px*3+3])
+		rgba[px*4+3] = 255
+	}
+	return rgba, nil
+}
+
+func (d *pngStreamDecoder) close() {
+	d.zr.Close()
+}
+
+// chunkWriter writes every byte slice it is given as a single PNG chunk
+// of the given type, so a zlib.Writer flushing into it naturally produces
+// a stream of IDAT chunks without ever buffering the whole encoded image.
+type chunkWriter struct {
+	w       io.Writer
+	chkType string
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := writeChunk(cw.w, cw.chkType, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func writeChunk(w io.Writer, typ string, data []byte) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(data)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(typ)); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	var crcBuf [4]byte
+	binary.BigEndian.PutUint32(crcBuf[:], crc.Sum32())
+	_, err := w.Write(crcBuf[:])
+	return err
+}
+
+// streamXORPNGs drives two pngStreamDecoders in lockstep, XORing one
+// scanline at a time and writing the result straight into a freshly
+// encoded PNG, so neither input nor output ever holds a full in-memory
+// image buffer.
+func streamXORPNGs(path1, path2 string, w io.Writer) error {
+	dec1, err := newPNGStreamDecoder(path1)
+	if err != nil {
+		return err
+	}
+	defer dec1.close()
+
+	dec2, err := newPNGStreamDecoder(path2)
+	if err != nil {
+		return err
+	}
+	defer dec2.close()
+
+	if dec1.width != dec2.width || dec1.height != dec2.height {
+		log.Fatalf("Error: Images have different dimensions\nImage1: %dx%d\nImage2: %dx%d",
+			dec1.width, dec1.height, dec2.width, dec2.height)
+	}
+
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+
+	var ihdr [13]byte
+	binary.BigEndian.PutUint32(ihdr[0:4], uint32(dec1.width))
+	binary.BigEndian.PutUint32(ihdr[4:8], uint32(dec1.height))
+	ihdr[8] = 8  // bit depth
+	ihdr[9] = 6  // color type: truecolor with alpha
+	ihdr[10] = 0 // compression method
+	ihdr[11] = 0 // filter method
+	ihdr[12] = 0 // interlace method
+	if err := writeChunk(w, "IHDR", ihdr[:]); err != nil {
+		return err
+	}
+
+	zw := zlib.NewWriter(&chunkWriter{w: w, chkType: "IDAT"})
+	rowLen := dec1.width * 4
+	out := make([]byte, rowLen+1) // leading filter byte, always 0 (None)
+	for y := 0; y < dec1.height; y++ {
+		row1, err := dec1.nextRow()
+		if err != nil {
+			return err
+		}
+		row2, err := dec2.nextRow()
+		if err != nil {
+			return err
+		}
+		for px := 0; px < dec1.width; px++ {
+			base := px * 4
+			out[base+1] = row1[base] ^ row2[base]     // R
+			out[base+2] = row1[base+1] ^ row2[base+1] // G
+			out[base+3] = row1[base+2] ^ row2[base+2] // B
+			out[base+4] = 255                         // A
+		}
+		if _, err := zw.Write(out); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	return writeChunk(w, "IEND", nil)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestPNG(t *testing.T, path string, width, height int) *image.RGBA {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if _, err := rand.Read(img.Pix); err != nil {
+		t.Fatalf("generating random pixels: %v", err)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			img.Set(x, y, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 255})
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("encoding %s: %v", path, err)
+	}
+	return img
+}
+
+func TestStreamXORPNGsMatchesDirectXOR(t *testing.T) {
+	dir := t.TempDir()
+	path1 := filepath.Join(dir, "a.png")
+	path2 := filepath.Join(dir, "b.png")
+	img1 := writeTestPNG(t, path1, 5, 3)
+	img2 := writeTestPNG(t, path2, 5, 3)
+
+	var buf bytes.Buffer
+	if err := streamXORPNGs(path1, path2, &buf); err != nil {
+		t.Fatalf("streamXORPNGs: %v", err)
+	}
+
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decoding stream output: %v", err)
+	}
+	result := imageToRGBA(decoded)
+
+	bounds := img1.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p1 := img1.RGBAAt(x, y)
+			p2 := img2.RGBAAt(x, y)
+			want := color.RGBA{p1.R ^ p2.R, p1.G ^ p2.G, p1.B ^ p2.B, 255}
+			got := result.RGBAAt(x, y)
+			if got != want {
+				t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestNewPNGStreamDecoderRejectsInterlacedPNG(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "interlaced.png")
+
+	var ihdr [13]byte
+	binary.BigEndian.PutUint32(ihdr[0:4], 4)
+	binary.BigEndian.PutUint32(ihdr[4:8], 4)
+	ihdr[8] = 8  // bit depth
+	ihdr[9] = 6  // color type: truecolor with alpha
+	ihdr[10] = 0 // compression method
+	ihdr[11] = 0 // filter method
+	ihdr[12] = 1 // interlace method: Adam7
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating %s: %v", path, err)
+	}
+	if _, err := f.Write(pngSignature); err != nil {
+		t.Fatalf("writing signature: %v", err)
+	}
+	if err := writeChunk(f, "IHDR", ihdr[:]); err != nil {
+		t.Fatalf("writing IHDR: %v", err)
+	}
+	f.Close()
+
+	if _, err := newPNGStreamDecoder(path); err == nil {
+		t.Fatal("expected an error for an Adam7-interlaced PNG, got nil")
+	}
+}
@@ -6,16 +6,20 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/gif"
+	"image/jpeg"
 	"image/png"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 )
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "Usage: %s [-i1 <first.png> -i2 <second.png>] [-g size -n count] > output.png\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Usage: %s [-i1 <first.png> -i2 <second.png>] [-g size -n count] [-shares N] [-combine paths] > output.png\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "\nDescription:\n")
-	fmt.Fprintf(os.Stderr, "  XORs two PNG images pixel by pixel or generates random noise images\n")
+	fmt.Fprintf(os.Stderr, "  XORs two PNG images pixel by pixel, generates random noise images,\n")
+	fmt.Fprintf(os.Stderr, "  or splits/combines an image into N-way XOR secret shares\n")
 	fmt.Fprintf(os.Stderr, "\nFlags:\n")
 	flag.PrintDefaults()
 	fmt.Fprintf(os.Stderr, "\nExamples:\n")
@@ -23,6 +27,19 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "    %s -i1 image1.png -i2 image2.png > result.png\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "  Generate multiple random noise images:\n")
 	fmt.Fprintf(os.Stderr, "    %s -g 480 -n 5\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  Split an image into 3 shares, then reconstruct it:\n")
+	fmt.Fprintf(os.Stderr, "    %s -i1 secret.png -shares 3\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "    %s -combine k-abcd1234-1.png,k-abcd1234-2.png,k-abcd1234-3.png > secret.png\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  XOR a JPEG and a GIF, emitting JPEG:\n")
+	fmt.Fprintf(os.Stderr, "    %s -i1 image1.jpg -i2 image2.gif -format jpeg -quality 85 > result.jpg\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  XOR two differently-sized images by resizing the second to fit:\n")
+	fmt.Fprintf(os.Stderr, "    %s -i1 big.png -i2 small.png -fit resize > result.png\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  XOR two animated GIFs frame by frame:\n")
+	fmt.Fprintf(os.Stderr, "    %s -i1 anim1.gif -i2 anim2.gif > result.gif\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  XOR two huge PNGs without decoding them fully into memory:\n")
+	fmt.Fprintf(os.Stderr, "    %s -i1 huge1.png -i2 huge2.png -stream > result.png\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  Stamp a logo in the bottom-right corner:\n")
+	fmt.Fprintf(os.Stderr, "    %s -i1 photo.png -i2 logo.png -mode over -offset 1800,1000 > result.png\n", os.Args[0])
 }
 
 func imageToRGBA(src image.Image) *image.RGBA {
@@ -30,30 +47,30 @@ func imageToRGBA(src image.Image) *image.RGBA {
 	dst := image.NewRGBA(bounds)
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			r, g, b, _ := src.At(x, y).RGBA()
+			r, g, b, a := src.At(x, y).RGBA()
 			dst.Set(x, y, color.RGBA{
 				uint8(r >> 8),
 				uint8(g >> 8),
 				uint8(b >> 8),
-				255,
+				uint8(a >> 8),
 			})
 		}
 	}
 	return dst
 }
 
-func generateRandomImage(size int) *image.RGBA {
-	img := image.NewRGBA(image.Rect(0, 0, size, size))
-	randomBytes := make([]byte, size*size*3)
-	
+func generateRandomImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	randomBytes := make([]byte, width*height*3)
+
 	_, err := rand.Read(randomBytes)
 	if err != nil {
 		log.Fatalf("Error generating random data: %v", err)
 	}
 
 	idx := 0
-	for y := 0; y < size; y++ {
-		for x := 0; x < size; x++ {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
 			r := randomBytes[idx]
 			g := randomBytes[idx+1]
 			b := randomBytes[idx+2]
@@ -72,12 +89,12 @@ func saveRandomImages(size, count int) {
 			log.Fatalf("Error creating file %s: %v", filename, err)
 		}
 
-		img := generateRandomImage(size)
+		img := generateRandomImage(size, size)
 		if err := png.Encode(f, img); err != nil {
 			f.Close()
 			log.Fatalf("Error encoding image %s: %v", filename, err)
 		}
-		
+
 		f.Close()
 		absPath, _ := filepath.Abs(filename)
 		fmt.Printf("Generated: %s\n", absPath)
@@ -85,10 +102,19 @@ func saveRandomImages(size, count int) {
 }
 
 func main() {
-	img1Path := flag.String("i1", "", "Path to first PNG image")
-	img2Path := flag.String("i2", "", "Path to second PNG image")
+	img1Path := flag.String("i1", "", "Path to first image (PNG, JPEG, or GIF)")
+	img2Path := flag.String("i2", "", "Path to second image (PNG, JPEG, or GIF)")
 	genSize := flag.Int("g", 0, "Generate random noise image with specified size")
 	numImages := flag.Int("n", 1, "Number of random images to generate")
+	numShares := flag.Int("shares", 0, "Split -i1 into N one-time-pad shares (use with -i1)")
+	combine := flag.String("combine", "", "Combine shares via XOR: comma-separated paths or a directory of shares")
+	outFormat := flag.String("format", "png", "Output format for the XOR result: png, jpeg, or gif")
+	quality := flag.Int("quality", 90, "JPEG output quality (1-100), used when -format jpeg")
+	fit := flag.String("fit", "none", "How to reconcile mismatched dimensions: none, crop, pad, or resize")
+	paletteMode := flag.String("palette", "global", "GIF output palette: global (shared across frames) or per-frame")
+	stream := flag.Bool("stream", false, "XOR large PNGs row by row instead of decoding full images into memory")
+	mode := flag.String("mode", "xor", "Compositing mode: xor, over, under, add, sub, mul, or screen")
+	offset := flag.String("offset", "0,0", "Position the second image at x,y within the first's bounds")
 	flag.Parse()
 
 	if *genSize > 0 {
@@ -96,6 +122,35 @@ func main() {
 		return
 	}
 
+	if *combine != "" {
+		if fileInfo, _ := os.Stdout.Stat(); (fileInfo.Mode() & os.ModeCharDevice) != 0 {
+			log.Fatal("Error: Output needs to be piped to a file")
+		}
+		result := combineShares(combineSharePaths(*combine))
+		if err := png.Encode(os.Stdout, shareAsNRGBA(result)); err != nil {
+			log.Fatalf("Error encoding combined image: %v", err)
+		}
+		return
+	}
+
+	if *numShares > 0 {
+		if *img1Path == "" {
+			log.Fatal("Error: -shares requires -i1")
+		}
+		img1File, err := os.Open(*img1Path)
+		if err != nil {
+			log.Fatalf("Error opening first image: %v", err)
+		}
+		defer img1File.Close()
+
+		originalImg1, err := png.Decode(img1File)
+		if err != nil {
+			log.Fatalf("Error decoding first image: %v", err)
+		}
+		saveShares(decodeShareImage(originalImg1), *numShares)
+		return
+	}
+
 	if *img1Path == "" || *img2Path == "" {
 		flag.Usage()
 		os.Exit(1)
@@ -105,13 +160,26 @@ func main() {
 		log.Fatal("Error: Output needs to be piped to a file")
 	}
 
+	if isGIFPath(*img1Path) && isGIFPath(*img2Path) {
+		offX, offY := parseOffset(*offset)
+		xorAnimatedGIFs(*img1Path, *img2Path, *fit, *paletteMode, *mode, offX, offY)
+		return
+	}
+
+	if *stream {
+		if err := streamXORPNGs(*img1Path, *img2Path, os.Stdout); err != nil {
+			log.Fatalf("Error streaming XOR result: %v", err)
+		}
+		return
+	}
+
 	img1File, err := os.Open(*img1Path)
 	if err != nil {
 		log.Fatalf("Error opening first image: %v", err)
 	}
 	defer img1File.Close()
 
-	originalImg1, err := png.Decode(img1File)
+	originalImg1, _, err := image.Decode(img1File)
 	if err != nil {
 		log.Fatalf("Error decoding first image: %v", err)
 	}
@@ -123,28 +191,43 @@ func main() {
 	}
 	defer img2File.Close()
 
-	originalImg2, err := png.Decode(img2File)
+	originalImg2, _, err := image.Decode(img2File)
 	if err != nil {
 		log.Fatalf("Error decoding second image: %v", err)
 	}
 	img2 := imageToRGBA(originalImg2)
 
-	bounds1 := img1.Bounds()
-	bounds2 := img2.Bounds()
-	if bounds1.Dx() != bounds2.Dx() || bounds1.Dy() != bounds2.Dy() {
-		log.Fatalf("Error: Images have different dimensions\nImage1: %dx%d\nImage2: %dx%d",
-			bounds1.Dx(), bounds1.Dy(), bounds2.Dx(), bounds2.Dy())
+	offX, offY := parseOffset(*offset)
+
+	if *mode == "xor" && offX == 0 && offY == 0 {
+		bounds1 := img1.Bounds()
+		bounds2 := img2.Bounds()
+		if bounds1.Dx() != bounds2.Dx() || bounds1.Dy() != bounds2.Dy() {
+			if *fit == "none" {
+				log.Fatalf("Error: Images have different dimensions\nImage1: %dx%d\nImage2: %dx%d",
+					bounds1.Dx(), bounds1.Dy(), bounds2.Dx(), bounds2.Dy())
+			}
+			img1, img2 = fitImages(img1, img2, *fit)
+		}
 	}
 
-	result := image.NewRGBA(bounds1)
-	for i := 0; i < len(img1.Pix); i += 4 {
-		result.Pix[i] = img1.Pix[i] ^ img2.Pix[i]         // R
-		result.Pix[i+1] = img1.Pix[i+1] ^ img2.Pix[i+1]   // G
-		result.Pix[i+2] = img1.Pix[i+2] ^ img2.Pix[i+2]   // B
-		result.Pix[i+3] = 255                             // A
-	}
+	result := compositeImages(img1, img2, *mode, offX, offY)
 
-	if err := png.Encode(os.Stdout, result); err != nil {
+	if err := encodeOutput(os.Stdout, result, *outFormat, *quality); err != nil {
 		log.Fatalf("Error encoding result image: %v", err)
 	}
 }
+
+// encodeOutput writes img to w using the requested output format.
+func encodeOutput(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case "png":
+		return png.Encode(w, img)
+	case "jpeg", "jpg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "gif":
+		return gif.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("unsupported -format %q (want png, jpeg, or gif)", format)
+	}
+}